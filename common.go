@@ -3,47 +3,78 @@
 
 // Package cfgp is a configuration parser fo Go.
 //
-// Just define a struct with needed configurations. Values are then taken from multiple source
-// in this order of precendece:
+// Just define a struct with needed configurations. Values are then taken from multiple source,
+// each overriding the previous one only where it actually sets a value, in this order of
+// precedence, lowest to highest:
 //
+// 	- configuration file
 // 	- env variables
 // 	- command line arguments (which are automagically created and parsed)
-// 	- configuration file
+//
+// Parser.Source reports which of these actually set a given field.
 //
 // Tags
 //
 // Default is to use field names in struct to create flags,
 // search for env variables and configuration into files.
 // Tags can be used to specify different name, flag help message
-// in command line, and section in conf file.
-// Format is:
+// and section in conf file, either with the legacy positional format:
 //	<name>,<help message>,<section in file>
+// or with named keys, which also allow overriding the env variable name
+// and marking a field as required:
+//	cfgp:"name=port,help=TCP port to listen on,section=http,env=PORT,required"
+// Parse returns a *RequiredFieldError listing every required field left
+// unset by all sources.
 //
-// For file, only INI format supported for now. Files must follows INI informal standard:
+// Struct fields can themselves be structs, to group related options
+// (e.g. an HTTP struct with Host and Port fields). Flag names and env
+// variable names are then dotted/underscored with the parent field name,
+// e.g. http.host and HTTP_HOST, and the parent field name becomes the
+// section used to look the group up in the configuration file.
+// Anonymous (embedded) structs flatten into the enclosing level instead.
+//
+// For file, INI, YAML, JSON and TOML formats are supported, dispatched by
+// file extension. INI files must follow the informal standard:
 //
 //	https://en.wikipedia.org/wiki/INI_file
 //
-// It tries to be modular and easily extendible to support different formats.
+// It tries to be modular and easily extendible to support different formats:
+// RegisterDecoder plugs in a Decoder for any other extension.
+//
+// -help/-h lists, for every field, its env var, file section/key, default
+// and current value with the source that set it. -print-config dumps the
+// fully-resolved struct back out in the file format it was loaded from when
+// that is JSON, YAML or TOML; any other format, including ones added with
+// RegisterDecoder, is printed as INI.
 // This is a work in progress, better packages are out there.
 package cfgp
 
 import (
+	"encoding"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/eraclitux/stracer"
 )
 
 var ErrNeedPointer = errors.New("cfgp: pointer to struct expected")
-var ErrFileFormat = errors.New("cfgp: unrecognized file format, only (ini|txt|cfg) supported")
+var ErrFileFormat = errors.New("cfgp: unrecognized file format, no Decoder registered for this extension")
 var ErrUnknownFlagType = errors.New("cfgp: unknown flag type")
 
+// ErrPrintConfig is returned by Parser.ParseArgs when -print-config was
+// passed: the resolved configuration has already been written to the
+// Parser's output, and the caller should treat this the same way it
+// would treat flag.ErrHelp. With ExitOnError (Parse's and NewParser's
+// default) the process exits with status 0 instead and nothing is returned.
+var ErrPrintConfig = errors.New("cfgp: configuration printed")
+
 func getStructValue(confPtr interface{}) (reflect.Value, error) {
 	v := reflect.ValueOf(confPtr)
 	if v.Kind() == reflect.Ptr {
@@ -73,61 +104,156 @@ func (s *myFlag) IsBoolFlag() bool {
 
 func (s *myFlag) Set(arg string) error {
 	stracer.Traceln("setting flag", s.field.Name)
-	switch s.fieldValue.Kind() {
-	case reflect.Int:
-		n, err := strconv.Atoi(arg)
+	return setValue(s.fieldValue, arg)
+}
+
+// textUnmarshalerType is used to detect, via v.Addr(), that a field
+// implements encoding.TextUnmarshaler and should have arg delegated to it
+// instead of being converted through v's Kind.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue converts arg to v's type and assigns it. It backs myFlag.Set
+// as well as the built-in Decoders, so every source agrees on which Go
+// types are supported and how a string is converted into them.
+//
+// Supported: all signed/unsigned int widths, float32/64, string, bool,
+// time.Duration (parsed with time.ParseDuration), comma-separated slices
+// of any of the above, and any type implementing encoding.TextUnmarshaler
+// (e.g. net.IP, time.Time), which takes priority over the Kind-based switch.
+func setValue(v reflect.Value, arg string) error {
+	if v.CanAddr() {
+		if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return tu.UnmarshalText([]byte(arg))
+		}
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(arg, 10, 64)
 		if err != nil {
 			return err
 		}
-		s.fieldValue.SetInt(int64(n))
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
 	case reflect.String:
-		s.fieldValue.SetString(arg)
+		v.SetString(arg)
 	case reflect.Bool:
 		b, err := strconv.ParseBool(arg)
 		if err != nil {
 			return err
 		}
-		s.fieldValue.SetBool(b)
+		v.SetBool(b)
+	case reflect.Slice:
+		return setSlice(v, arg)
 	default:
 		return ErrUnknownFlagType
 	}
 	return nil
 }
 
-func helpMessageFromTags(f reflect.StructField) (string, bool) {
-	t := f.Tag.Get("cfgp")
-	tags := strings.Split(t, ",")
-	if len(tags) == 3 {
-		return tags[1], true
+// formatValue is setValue's inverse: it renders v back into the string
+// form setValue would accept, for use in -help's "current value" display
+// and in Parser.ParseArgs' -print-config renderer.
+func formatValue(v reflect.Value) string {
+	if v.CanAddr() {
+		if tm, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			if b, err := tm.MarshalText(); err == nil {
+				return string(b)
+			}
+		}
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
 	}
-	return "", false
+	if v.Kind() == reflect.Slice {
+		parts := make([]string, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprintf("%v", v.Interface())
 }
 
-func makeHelpMessage(f reflect.StructField) string {
-	var helpM string
-	switch f.Type.Kind() {
-	case reflect.Int:
-		if m, ok := helpMessageFromTags(f); ok {
-			helpM = m + ", an int value"
-		} else {
-			helpM = "set an int value"
+// setSlice splits arg on commas and assigns each trimmed element to a new
+// slice of v's element type, via setValue, so slices of any supported
+// primitive (or TextUnmarshaler) are handled for free.
+func setSlice(v reflect.Value, arg string) error {
+	parts := strings.Split(arg, ",")
+	slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+	for i, p := range parts {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := setValue(elem, strings.TrimSpace(p)); err != nil {
+			return err
 		}
+		slice.Index(i).Set(elem)
+	}
+	v.Set(slice)
+	return nil
+}
+
+func makeHelpMessage(f reflect.StructField) string {
+	kind := describeType(f.Type)
+	if m, ok := helpMessageFromTags(f); ok {
+		return m + ", " + kind
+	}
+	return "set " + kind
+}
+
+// describeType returns the human-readable fragment makeHelpMessage appends
+// to a flag's help text, e.g. "an int value" or "a comma-separated list of
+// string values". It mirrors the types setValue knows how to assign.
+func describeType(t reflect.Type) string {
+	if t == durationType {
+		return "a duration value (e.g. \"30s\", \"5m\")"
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return "a " + t.String() + " value"
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an int value"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a uint value"
+	case reflect.Float32, reflect.Float64:
+		return "a float value"
 	case reflect.String:
-		if m, ok := helpMessageFromTags(f); ok {
-			helpM = m + ", a string value"
-		} else {
-			helpM = "set a string value"
-		}
+		return "a string value"
 	case reflect.Bool:
-		if m, ok := helpMessageFromTags(f); ok {
-			helpM = m + ", a bool value"
-		} else {
-			helpM = "set a bool value"
-		}
+		return "a bool value"
+	case reflect.Slice:
+		return "a comma-separated list of " + pluralizeDesc(describeType(t.Elem()))
 	default:
-		helpM = "unknown flag kind"
+		return "unknown flag kind"
 	}
-	return helpM
+}
+
+// pluralizeDesc turns a describeType fragment like "an int value" into
+// "int values", for use after "a comma-separated list of".
+func pluralizeDesc(desc string) string {
+	desc = strings.TrimPrefix(desc, "an ")
+	desc = strings.TrimPrefix(desc, "a ")
+	return strings.Replace(desc, " value", " values", 1)
 }
 
 func isBool(v reflect.Value) bool {
@@ -137,70 +263,90 @@ func isBool(v reflect.Value) bool {
 	return false
 }
 
-func nameFromTags(f reflect.StructField) (string, bool) {
-	t := f.Tag.Get("cfgp")
-	tags := strings.Split(t, ",")
-	if len(tags) == 3 {
-		return tags[0], true
-	}
-	return "", false
-}
-
-// FIXME can we semplify using structType := structValue.Type()?
-func createFlag(f reflect.StructField, fieldValue reflect.Value, fs *flag.FlagSet) {
-	name := strings.ToLower(f.Name)
-	if n, ok := nameFromTags(f); ok {
-		name = n
-	}
-	stracer.Traceln("creating flag:", name)
-	fs.Var(&myFlag{f, fieldValue, isBool(fieldValue)}, name, makeHelpMessage(f))
+func createFlag(f reflect.StructField, fieldValue reflect.Value, flagName string, fs *flag.FlagSet) {
+	stracer.Traceln("creating flag:", flagName)
+	fs.Var(&myFlag{f, fieldValue, isBool(fieldValue)}, flagName, makeHelpMessage(f))
 }
 
-func parseFlags(s reflect.Value) error {
-	flagSet := flag.NewFlagSet("cfgp", flag.ExitOnError)
-	flagSet.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
-		flagSet.PrintDefaults()
-	}
+// walkFields recursively descends s, calling visit for every leaf field
+// (non-struct, or a struct implementing encoding.TextUnmarshaler) that CanSet. Nested struct fields add their own
+// name, lowercased, as a dotted segment of flagName (e.g. "http.host")
+// and an underscore segment of envName (e.g. "HTTP_HOST"); anonymous
+// (embedded) struct fields flatten instead, matching sflags convention.
+// section follows the same nesting rule and is what Decoders use to
+// group fields, see sectionFromTags for the tag that overrides it. name
+// is the field's own, unprefixed name, i.e. the key a Decoder looks up
+// inside that section.
+func walkFields(s reflect.Value, flagPrefix, envPrefix []string, section string, visit func(f reflect.StructField, fieldValue reflect.Value, flagName, envName, section, name string)) {
 	typeOfT := s.Type()
 	for i := 0; i < s.NumField(); i++ {
 		fieldValue := s.Field(i)
-		if fieldValue.CanSet() {
-			createFlag(typeOfT.Field(i), fieldValue, flagSet)
+		if !fieldValue.CanSet() {
+			continue
+		}
+		f := typeOfT.Field(i)
+		name := strings.ToLower(f.Name)
+		if n, ok := nameFromTags(f); ok {
+			name = n
+		}
+		isTextUnmarshaler := fieldValue.CanAddr() && fieldValue.Addr().Type().Implements(textUnmarshalerType)
+		if fieldValue.Kind() == reflect.Struct && !isTextUnmarshaler {
+			nestedFlagPrefix, nestedEnvPrefix, nestedSection := flagPrefix, envPrefix, section
+			if !f.Anonymous {
+				nestedFlagPrefix = append(append([]string{}, flagPrefix...), name)
+				nestedEnvPrefix = append(append([]string{}, envPrefix...), strings.ToUpper(f.Name))
+				if nestedSection == "" {
+					nestedSection = name
+				} else {
+					nestedSection = nestedSection + "." + name
+				}
+			}
+			if s2, ok := sectionFromTags(f); ok {
+				nestedSection = s2
+			}
+			walkFields(fieldValue, nestedFlagPrefix, nestedEnvPrefix, nestedSection, visit)
+			continue
+		}
+		flagName := strings.Join(append(append([]string{}, flagPrefix...), name), ".")
+		envName := strings.Join(append(append([]string{}, envPrefix...), strings.ToUpper(f.Name)), "_")
+		if e, ok := envFromTags(f); ok {
+			envName = e
 		}
+		leafSection := section
+		if s2, ok := sectionFromTags(f); ok {
+			leafSection = s2
+		}
+		visit(f, fieldValue, flagName, envName, leafSection, name)
 	}
-	err := flagSet.Parse(os.Args[1:])
+}
+
+// decodeFile opens path, picks the Decoder registered for its extension
+// and uses it to populate structValue. It's called by Parser.ParseArgs,
+// and so by Parse through it. The returned set holds the dotted flagName of every
+// field the Decoder actually found a value for, so callers can tell a
+// field loaded from the file apart from one left at its zero value.
+func decodeFile(path string, structValue reflect.Value) (map[string]bool, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	d, ok := decoderFor(ext)
+	if !ok {
+		return nil, ErrFileFormat
+	}
+	f, err := os.Open(path)
 	if err != nil {
-		stracer.Traceln("this is not executed")
-		return err
+		return nil, err
 	}
-	return nil
+	defer f.Close()
+	return d.Decode(f, structValue)
 }
 
 // Parse popolate passed struct (via pointer) with configuration from varoius source.
-// It guesses configuration type by file extention and call specific parser.
-// (.ini|.txt|.cfg) are evaluated as INI files which is to only format supported for now.
+// It guesses configuration type by file extention and dispatches to the
+// Decoder registered for it, see RegisterDecoder.
 // path can be an empty string to disable file parsing.
+//
+// Parse is a thin wrapper around a default Parser and always parses
+// os.Args, exiting the process on a bad flag. Use Parser directly to
+// control error handling or to parse an explicit argument slice.
 func Parse(path string, confPtr interface{}) error {
-	structValue, err := getStructValue(confPtr)
-	if err != nil {
-		return err
-	}
-	if path != "" {
-		if match, _ := regexp.MatchString(`\.(ini|txt|cfg)$`, path); match {
-			err := parseINI(path, structValue)
-			if err != nil {
-				return err
-			}
-		} else if match, _ := regexp.MatchString(`\.(yaml)$`, path); match {
-			return errors.New("YAML not yet implemented. Want you help?")
-		} else {
-			return ErrFileFormat
-		}
-	}
-	err = parseFlags(structValue)
-	if err != nil {
-		return err
-	}
-	return nil
+	return NewParser().ParseArgs(path, confPtr, os.Args[1:])
 }
@@ -0,0 +1,48 @@
+package cfgp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A struct-shaped encoding.TextUnmarshaler, like time.Time, must be
+// walked as a leaf field: it has no settable fields of its own, so
+// recursing into it silently drops the field instead of producing a
+// flag/env entry for it.
+func TestWalkFieldsTreatsTextUnmarshalerStructAsLeaf(t *testing.T) {
+	type Config struct {
+		Start time.Time
+		Port  int
+	}
+	var cfg Config
+	v := reflect.ValueOf(&cfg).Elem()
+
+	var visited []string
+	walkFields(v, nil, nil, "", func(f reflect.StructField, fieldValue reflect.Value, flagName, envName, section, name string) {
+		visited = append(visited, flagName)
+	})
+
+	want := map[string]bool{"start": true, "port": true}
+	got := map[string]bool{}
+	for _, v := range visited {
+		got[v] = true
+	}
+	for name := range want {
+		if !got[name] {
+			t.Fatalf("walkFields did not visit %q, visited: %v", name, visited)
+		}
+	}
+}
+
+func TestSetValueTextUnmarshaler(t *testing.T) {
+	var start time.Time
+	v := reflect.ValueOf(&start).Elem()
+	if err := setValue(v, "2020-01-02T15:04:05Z"); err != nil {
+		t.Fatalf("setValue: %v", err)
+	}
+	want := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	if !start.Equal(want) {
+		t.Fatalf("start = %v, want %v", start, want)
+	}
+}
@@ -0,0 +1,141 @@
+package cfgp
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Decoder reads configuration data from r and assigns matching values onto
+// the fields of v, which is the addressable struct value passed to Parse.
+// Implementations should ignore keys that don't match any field, so the
+// same file can carry settings unrelated to the struct being populated.
+// The returned set holds the dotted flagName (see walkFields) of every
+// field a value was actually found for, so Parser can tell a field loaded
+// from the file apart from one left at its zero value.
+type Decoder interface {
+	Decode(r io.Reader, v reflect.Value) (set map[string]bool, err error)
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = make(map[string]Decoder)
+)
+
+// RegisterDecoder associates a Decoder with a file extension, without the
+// leading dot (e.g. "yaml"). Parse consults this registry to pick the
+// Decoder matching the configuration file's extension, so callers can plug
+// in support for formats cfgp doesn't ship with, or override a built-in one.
+func RegisterDecoder(ext string, d Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[strings.ToLower(ext)] = d
+}
+
+func decoderFor(ext string) (Decoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	d, ok := decoders[ext]
+	return d, ok
+}
+
+func init() {
+	RegisterDecoder("ini", iniDecoder{})
+	RegisterDecoder("txt", iniDecoder{})
+	RegisterDecoder("cfg", iniDecoder{})
+	RegisterDecoder("yaml", yamlDecoder{})
+	RegisterDecoder("yml", yamlDecoder{})
+	RegisterDecoder("json", jsonDecoder{})
+	RegisterDecoder("toml", tomlDecoder{})
+}
+
+// applyMap walks v's fields and, for each one that has a matching entry in
+// data (looked up by tag name or lowercased field name), converts the
+// value to a string and assigns it via setValue. It's shared by the
+// Decoders that naturally unmarshal into a generic map (YAML, JSON, TOML),
+// so they all honour cfgp's own naming and tagging rules instead of their
+// own (e.g. `json` or `yaml` struct tags).
+//
+// Nested, non-anonymous struct fields are looked up as a sub-map keyed by
+// the field name (or its `cfgp` tag's "section" override, see
+// sectionFromTags), mirroring how these formats nest sections natively;
+// anonymous struct fields flatten into data instead.
+func applyMap(data map[string]interface{}, v reflect.Value) (map[string]bool, error) {
+	set := make(map[string]bool)
+	if err := applyMapInto(data, v, nil, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// applyMapInto does the recursive work for applyMap, tracking the dotted
+// flagName (namePrefix joined with each field's own name) of every field
+// it actually sets into set.
+func applyMapInto(data map[string]interface{}, v reflect.Value, namePrefix []string, set map[string]bool) error {
+	typeOfT := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		fieldValue := v.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+		f := typeOfT.Field(i)
+		name := strings.ToLower(f.Name)
+		if n, ok := nameFromTags(f); ok {
+			name = n
+		}
+		isTextUnmarshaler := fieldValue.CanAddr() && fieldValue.Addr().Type().Implements(textUnmarshalerType)
+		if fieldValue.Kind() == reflect.Struct && !isTextUnmarshaler {
+			if f.Anonymous {
+				if err := applyMapInto(data, fieldValue, namePrefix, set); err != nil {
+					return err
+				}
+				continue
+			}
+			sectionKey := name
+			if s2, ok := sectionFromTags(f); ok {
+				sectionKey = s2
+			}
+			raw, ok := data[sectionKey]
+			if !ok {
+				continue
+			}
+			sub, ok := toStringMap(raw)
+			if !ok {
+				continue
+			}
+			if err := applyMapInto(sub, fieldValue, append(append([]string{}, namePrefix...), name), set); err != nil {
+				return err
+			}
+			continue
+		}
+		raw, ok := data[name]
+		if !ok {
+			continue
+		}
+		if err := setValue(fieldValue, fmt.Sprintf("%v", raw)); err != nil {
+			return fmt.Errorf("cfgp: setting %q: %w", f.Name, err)
+		}
+		set[strings.Join(append(append([]string{}, namePrefix...), name), ".")] = true
+	}
+	return nil
+}
+
+// toStringMap normalizes the two shapes a nested section can take once
+// unmarshaled into interface{}: encoding/json and BurntSushi/toml produce
+// map[string]interface{}, while yaml.v2 produces map[interface{}]interface{}.
+func toStringMap(raw interface{}) (map[string]interface{}, bool) {
+	switch m := raw.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			out[fmt.Sprintf("%v", k)] = v
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,21 @@
+package cfgp
+
+import "testing"
+
+func TestDecoderForBuiltins(t *testing.T) {
+	for _, ext := range []string{"ini", "txt", "cfg", "yaml", "yml", "json", "toml"} {
+		if _, ok := decoderFor(ext); !ok {
+			t.Errorf("decoderFor(%q) = false, want a registered Decoder", ext)
+		}
+	}
+	if _, ok := decoderFor("unknown"); ok {
+		t.Error(`decoderFor("unknown") = true, want false`)
+	}
+}
+
+func TestRegisterDecoderIsCaseInsensitive(t *testing.T) {
+	RegisterDecoder("INI", iniDecoder{})
+	if _, ok := decoderFor("ini"); !ok {
+		t.Fatal(`decoderFor("ini") = false after RegisterDecoder("INI", ...), want true`)
+	}
+}
@@ -0,0 +1,33 @@
+package cfgp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyMapTextUnmarshalerLeaf(t *testing.T) {
+	type Config struct {
+		Start time.Time
+		Port  int
+	}
+	var cfg Config
+	data := map[string]interface{}{
+		"start": "2020-01-02T15:04:05Z",
+		"port":  8080,
+	}
+	set, err := applyMap(data, reflect.ValueOf(&cfg).Elem())
+	if err != nil {
+		t.Fatalf("applyMap: %v", err)
+	}
+	want := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	if !cfg.Start.Equal(want) {
+		t.Fatalf("Start = %v, want %v", cfg.Start, want)
+	}
+	if cfg.Port != 8080 {
+		t.Fatalf("Port = %d, want 8080", cfg.Port)
+	}
+	if !set["start"] || !set["port"] {
+		t.Fatalf("set = %v, want both start and port recorded", set)
+	}
+}
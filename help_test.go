@@ -0,0 +1,41 @@
+package cfgp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type helpTestConfig struct {
+	Port int
+}
+
+func TestParserPrintConfigReturnsErrPrintConfig(t *testing.T) {
+	var cfg helpTestConfig
+	p := NewParser()
+	p.ErrorHandling = ContinueOnError
+	var out bytes.Buffer
+	p.SetOutput(&out)
+	err := p.ParseArgs("", &cfg, []string{"-port", "9090", "-print-config"})
+	if err != ErrPrintConfig {
+		t.Fatalf("ParseArgs err = %v, want ErrPrintConfig", err)
+	}
+	if !strings.Contains(out.String(), "port=9090") {
+		t.Fatalf("printed config = %q, want it to contain port=9090", out.String())
+	}
+}
+
+func TestParserRenderUsageShowsEnvAndDefault(t *testing.T) {
+	var cfg helpTestConfig
+	p := NewParser()
+	p.ErrorHandling = ContinueOnError
+	var out bytes.Buffer
+	p.SetOutput(&out)
+	_ = p.ParseArgs("", &cfg, []string{"-h"})
+	usage := out.String()
+	for _, want := range []string{"-port", "env: PORT", `default: "0"`, "-print-config"} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("usage output missing %q, got:\n%s", want, usage)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cfgp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// iniDecoder implements Decoder for the informal INI format described in
+// the package doc: https://en.wikipedia.org/wiki/INI_file
+// Top-level fields are matched against an unsectioned key unless their
+// `cfgp` tag names a section, see sectionFromTags; fields of nested
+// structs are looked up in their parent's section, see decodeINIStruct.
+type iniDecoder struct{}
+
+func (iniDecoder) Decode(r io.Reader, v reflect.Value) (map[string]bool, error) {
+	data, err := scanINI(r)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool)
+	if err := decodeINIStruct(data, "", nil, v, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// decodeINIStruct recursively walks v, looking up each leaf field in
+// data[section][name]. Nested, non-anonymous struct fields descend into
+// a section named after the field (or the dotted parent.child section
+// for deeper nesting), matching the naming rules in walkFields; anonymous
+// struct fields flatten into the enclosing section instead. Every field
+// actually found is recorded in set under its dotted flagName.
+func decodeINIStruct(data map[string]map[string]string, section string, namePrefix []string, v reflect.Value, set map[string]bool) error {
+	typeOfT := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		fieldValue := v.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+		f := typeOfT.Field(i)
+		name := strings.ToLower(f.Name)
+		if n, ok := nameFromTags(f); ok {
+			name = n
+		}
+		isTextUnmarshaler := fieldValue.CanAddr() && fieldValue.Addr().Type().Implements(textUnmarshalerType)
+		if fieldValue.Kind() == reflect.Struct && !isTextUnmarshaler {
+			nestedSection, nestedPrefix := section, namePrefix
+			if !f.Anonymous {
+				if nestedSection == "" {
+					nestedSection = name
+				} else {
+					nestedSection = nestedSection + "." + name
+				}
+				nestedPrefix = append(append([]string{}, namePrefix...), name)
+			}
+			if s2, ok := sectionFromTags(f); ok {
+				nestedSection = s2
+			}
+			if err := decodeINIStruct(data, nestedSection, nestedPrefix, fieldValue, set); err != nil {
+				return err
+			}
+			continue
+		}
+		leafSection := section
+		if s2, ok := sectionFromTags(f); ok {
+			leafSection = s2
+		}
+		raw, ok := data[leafSection][name]
+		if !ok {
+			continue
+		}
+		if err := setValue(fieldValue, raw); err != nil {
+			return fmt.Errorf("cfgp: setting %q from ini: %w", f.Name, err)
+		}
+		set[strings.Join(append(append([]string{}, namePrefix...), name), ".")] = true
+	}
+	return nil
+}
+
+// scanINI does a minimal scan of an INI stream into section -> key ->
+// value, skipping blank lines and ';'/'#' comments. The unsectioned part
+// of the file is stored under the "" key.
+func scanINI(r io.Reader) (map[string]map[string]string, error) {
+	data := map[string]map[string]string{"": {}}
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := data[section]; !ok {
+				data[section] = map[string]string{}
+			}
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		data[section][key] = strings.TrimSpace(parts[1])
+	}
+	return data, scanner.Err()
+}
@@ -0,0 +1,34 @@
+package cfgp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeINIStructTextUnmarshalerLeaf(t *testing.T) {
+	type Config struct {
+		Start time.Time
+		Port  int
+	}
+	var cfg Config
+	data, err := scanINI(strings.NewReader("start=2020-01-02T15:04:05Z\nport=8080\n"))
+	if err != nil {
+		t.Fatalf("scanINI: %v", err)
+	}
+	set := make(map[string]bool)
+	if err := decodeINIStruct(data, "", nil, reflect.ValueOf(&cfg).Elem(), set); err != nil {
+		t.Fatalf("decodeINIStruct: %v", err)
+	}
+	want := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	if !cfg.Start.Equal(want) {
+		t.Fatalf("Start = %v, want %v", cfg.Start, want)
+	}
+	if cfg.Port != 8080 {
+		t.Fatalf("Port = %d, want 8080", cfg.Port)
+	}
+	if !set["start"] || !set["port"] {
+		t.Fatalf("set = %v, want both start and port recorded", set)
+	}
+}
@@ -0,0 +1,19 @@
+package cfgp
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// jsonDecoder implements Decoder for JSON files. Keys are matched against
+// fields the same way as everywhere else in cfgp, see applyMap.
+type jsonDecoder struct{}
+
+func (jsonDecoder) Decode(r io.Reader, v reflect.Value) (map[string]bool, error) {
+	raw := make(map[string]interface{})
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return applyMap(raw, v)
+}
@@ -0,0 +1,238 @@
+package cfgp
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/eraclitux/stracer"
+)
+
+// ErrorHandling mirrors flag.ErrorHandling, so callers can configure a
+// Parser without importing the flag package themselves.
+type ErrorHandling flag.ErrorHandling
+
+const (
+	// ContinueOnError makes ParseArgs return the flag error instead of
+	// terminating.
+	ContinueOnError ErrorHandling = ErrorHandling(flag.ContinueOnError)
+	// ExitOnError calls os.Exit(2) on a bad flag, same as Parse always did.
+	ExitOnError ErrorHandling = ErrorHandling(flag.ExitOnError)
+	// PanicOnError panics instead of exiting, useful in tests that want a
+	// recoverable failure.
+	PanicOnError ErrorHandling = ErrorHandling(flag.PanicOnError)
+)
+
+// fieldInfo is what ParseArgs snapshots about a leaf field before any
+// source has had a chance to touch it, for -help's usage output.
+type fieldInfo struct {
+	envName    string
+	section    string
+	name       string
+	defaultVal string
+}
+
+// Parser drives cfgp without touching process-global state: unlike Parse,
+// it neither reads os.Args nor unconditionally terminates the process on a
+// parse error, which makes it safe to embed in libraries, cobra
+// subcommands, or a TestMain.
+type Parser struct {
+	// ErrorHandling controls what the underlying flag.FlagSet does on a
+	// parse error, see the flag package for the three modes' semantics.
+	ErrorHandling ErrorHandling
+	output        io.Writer
+	sources       map[string]Source
+	fields        map[string]fieldInfo
+	fileExt       string
+}
+
+// NewParser returns a Parser configured with ExitOnError, the same
+// behavior Parse has always had.
+func NewParser() *Parser {
+	return &Parser{ErrorHandling: ExitOnError}
+}
+
+// SetOutput sets where usage, -print-config and error messages are
+// written. Defaults to os.Stderr.
+func (p *Parser) SetOutput(w io.Writer) {
+	p.output = w
+}
+
+func (p *Parser) out() io.Writer {
+	if p.output != nil {
+		return p.output
+	}
+	return os.Stderr
+}
+
+// Source reports which source actually set the field registered under
+// flagName (its dotted name, e.g. "http.port"), or SourceDefault if
+// ParseArgs hasn't run yet or no source touched it.
+func (p *Parser) Source(flagName string) Source {
+	return p.sources[flagName]
+}
+
+// ParseArgs behaves like Parse but reads command line flags from args
+// instead of os.Args, and honours p.ErrorHandling instead of always
+// exiting the process on a bad flag.
+//
+// Sources are applied in order of precedence, lowest first, each one
+// overriding the previous only for the fields it actually sets: the
+// configuration file, then env variables, then args. Parser.Source
+// reports which one won for a given field afterwards. If any field
+// tagged `required` is left at SourceDefault once all sources have run,
+// ParseArgs returns a *RequiredFieldError listing every one of them.
+//
+// -help/-h prints, for every field, its flag name, env var, file
+// section/key, default value and current value with its source.
+// -print-config dumps the fully-resolved struct back out in the format
+// it was loaded from when that is json, yaml/yml or toml, and as ini
+// otherwise (no file given, ini/txt/cfg, or an extension added with
+// RegisterDecoder), then returns ErrPrintConfig, or exits with status 0
+// under ExitOnError.
+func (p *Parser) ParseArgs(path string, confPtr interface{}, args []string) error {
+	structValue, err := getStructValue(confPtr)
+	if err != nil {
+		return err
+	}
+	p.fileExt = "ini"
+	if path != "" {
+		if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext != "" {
+			p.fileExt = ext
+		}
+	}
+	p.sources = make(map[string]Source)
+	p.fields = make(map[string]fieldInfo)
+	walkFields(structValue, nil, nil, "", func(f reflect.StructField, fieldValue reflect.Value, flagName, envName, section, name string) {
+		p.sources[flagName] = SourceDefault
+		p.fields[flagName] = fieldInfo{envName: envName, section: section, name: name, defaultVal: formatValue(fieldValue)}
+	})
+	if path != "" {
+		set, err := decodeFile(path, structValue)
+		if err != nil {
+			return err
+		}
+		for flagName := range set {
+			p.sources[flagName] = SourceFile
+		}
+	}
+	if err := p.applyEnv(structValue); err != nil {
+		return err
+	}
+	if err := p.parseFlags(structValue, args); err != nil {
+		return err
+	}
+	return p.checkRequired(structValue)
+}
+
+// applyEnv overrides every field whose env variable is set in the
+// environment, recording SourceEnv for it.
+func (p *Parser) applyEnv(s reflect.Value) error {
+	var errs []string
+	walkFields(s, nil, nil, "", func(f reflect.StructField, fieldValue reflect.Value, flagName, envName, section, name string) {
+		raw, ok := os.LookupEnv(envName)
+		if !ok {
+			return
+		}
+		if err := setValue(fieldValue, raw); err != nil {
+			errs = append(errs, fmt.Sprintf("%s (from $%s): %s", flagName, envName, err))
+			return
+		}
+		p.sources[flagName] = SourceEnv
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("cfgp: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// printConfigFlagName is reserved for the -print-config flag parseFlags
+// adds itself; a struct field resolving to this flag name is rejected
+// rather than left to panic flag.FlagSet.Var with "flag redefined".
+const printConfigFlagName = "print-config"
+
+func (p *Parser) parseFlags(s reflect.Value, args []string) error {
+	flagSet := flag.NewFlagSet("cfgp", flag.ErrorHandling(p.ErrorHandling))
+	flagSet.SetOutput(p.out())
+	walkFields(s, nil, nil, "", func(f reflect.StructField, fieldValue reflect.Value, flagName, envName, section, name string) {
+		createFlag(f, fieldValue, flagName, flagSet)
+	})
+	if flagSet.Lookup(printConfigFlagName) != nil {
+		return fmt.Errorf("cfgp: %q is reserved for -print-config and can't be used as a field's flag name", printConfigFlagName)
+	}
+	printConfig := flagSet.Bool(printConfigFlagName, false, "print the fully-resolved configuration and exit")
+	flagSet.Usage = p.renderUsage(s)
+	if err := flagSet.Parse(args); err != nil {
+		stracer.Traceln("flag parsing failed:", err)
+		return err
+	}
+	flagSet.Visit(func(fl *flag.Flag) {
+		p.sources[fl.Name] = SourceFlag
+	})
+	if *printConfig {
+		if err := p.printConfig(s); err != nil {
+			return err
+		}
+		if p.ErrorHandling == ExitOnError {
+			os.Exit(0)
+		}
+		return ErrPrintConfig
+	}
+	return nil
+}
+
+// renderUsage replaces flag.FlagSet's default usage with one that also
+// shows, for every field, the env var it reads, the file section/key it
+// is loaded from, its default value, and its current value and source.
+func (p *Parser) renderUsage(s reflect.Value) func() {
+	return func() {
+		w := p.out()
+		fmt.Fprintf(w, "Usage of %s:\n", os.Args[0])
+		walkFields(s, nil, nil, "", func(f reflect.StructField, fieldValue reflect.Value, flagName, envName, section, name string) {
+			info := p.fields[flagName]
+			fmt.Fprintf(w, "  -%s\n", flagName)
+			fmt.Fprintf(w, "    \t%s\n", makeHelpMessage(f))
+			fileKey := name
+			if section != "" {
+				fileKey = section + "." + name
+			}
+			fmt.Fprintf(w, "    \tenv: %s, file key: %s, default: %q\n", envName, fileKey, info.defaultVal)
+			fmt.Fprintf(w, "    \tcurrent: %q (source: %s)\n", formatValue(fieldValue), p.sources[flagName])
+		})
+		fmt.Fprintln(w, "  -print-config")
+		fmt.Fprintln(w, "    \tprint the fully-resolved configuration and exit")
+	}
+}
+
+// printConfig writes s back out in p.fileExt's format.
+func (p *Parser) printConfig(s reflect.Value) error {
+	switch p.fileExt {
+	case "json":
+		return encodeJSON(p.out(), buildNestedMap(s))
+	case "yaml", "yml":
+		return encodeYAML(p.out(), buildNestedMap(s))
+	case "toml":
+		return encodeTOML(p.out(), buildNestedMap(s))
+	default:
+		return encodeINI(p.out(), s)
+	}
+}
+
+// checkRequired returns a *RequiredFieldError listing every field tagged
+// `required` that no source set, or nil if there are none.
+func (p *Parser) checkRequired(s reflect.Value) error {
+	var missing []string
+	walkFields(s, nil, nil, "", func(f reflect.StructField, fieldValue reflect.Value, flagName, envName, section, name string) {
+		if requiredFromTags(f) && p.sources[flagName] == SourceDefault {
+			missing = append(missing, flagName)
+		}
+	})
+	if len(missing) > 0 {
+		return &RequiredFieldError{Fields: missing}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package cfgp
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+type parserTestConfig struct {
+	Port int
+	Name string
+}
+
+func TestParserParseArgsFlagOverride(t *testing.T) {
+	var cfg parserTestConfig
+	p := NewParser()
+	p.ErrorHandling = ContinueOnError
+	p.SetOutput(&bytes.Buffer{})
+	if err := p.ParseArgs("", &cfg, []string{"-port", "9090", "-name", "svc"}); err != nil {
+		t.Fatalf("ParseArgs: %v", err)
+	}
+	if cfg.Port != 9090 || cfg.Name != "svc" {
+		t.Fatalf("cfg = %+v, want Port=9090 Name=svc", cfg)
+	}
+	if p.Source("port") != SourceFlag {
+		t.Fatalf("Source(port) = %v, want SourceFlag", p.Source("port"))
+	}
+}
+
+func TestParserParseArgsContinueOnErrorReturnsErr(t *testing.T) {
+	var cfg parserTestConfig
+	p := NewParser()
+	p.ErrorHandling = ContinueOnError
+	var out bytes.Buffer
+	p.SetOutput(&out)
+	err := p.ParseArgs("", &cfg, []string{"-not-a-flag"})
+	if err == nil {
+		t.Fatal("ParseArgs returned nil error for an unknown flag, want a non-nil error")
+	}
+}
+
+func TestParserSetOutputDefaultsToStderrWhenUnset(t *testing.T) {
+	p := NewParser()
+	if p.out() != os.Stderr {
+		t.Fatal("out() is not os.Stderr, want os.Stderr as the default writer")
+	}
+}
@@ -0,0 +1,42 @@
+package cfgp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source identifies which input actually set a field's value.
+type Source int
+
+const (
+	// SourceDefault means no source set the field; it's still at
+	// whatever value the struct literal gave it before Parse ran.
+	SourceDefault Source = iota
+	SourceFile
+	SourceEnv
+	SourceFlag
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceFile:
+		return "file"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	default:
+		return "default"
+	}
+}
+
+// RequiredFieldError is returned by Parse/ParseArgs when one or more
+// fields tagged `required` were left unset by every source.
+type RequiredFieldError struct {
+	// Fields holds the dotted flag name of every missing field.
+	Fields []string
+}
+
+func (e *RequiredFieldError) Error() string {
+	return fmt.Sprintf("cfgp: missing required fields: %s", strings.Join(e.Fields, ", "))
+}
@@ -0,0 +1,57 @@
+package cfgp
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+type precedenceTestConfig struct {
+	Port int
+	Host string `cfgp:"name=host,required"`
+}
+
+func TestParserPrecedenceEnvOverridesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/conf.ini"
+	if err := os.WriteFile(path, []byte("port=8080\nhost=file-host\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	os.Setenv("HOST", "env-host")
+	defer os.Unsetenv("HOST")
+
+	var cfg precedenceTestConfig
+	p := NewParser()
+	p.ErrorHandling = ContinueOnError
+	p.SetOutput(&bytes.Buffer{})
+	if err := p.ParseArgs(path, &cfg, nil); err != nil {
+		t.Fatalf("ParseArgs: %v", err)
+	}
+	if cfg.Host != "env-host" {
+		t.Fatalf("Host = %q, want env-host (env overrides file)", cfg.Host)
+	}
+	if p.Source("host") != SourceEnv {
+		t.Fatalf("Source(host) = %v, want SourceEnv", p.Source("host"))
+	}
+	if cfg.Port != 8080 {
+		t.Fatalf("Port = %d, want 8080 from file", cfg.Port)
+	}
+	if p.Source("port") != SourceFile {
+		t.Fatalf("Source(port) = %v, want SourceFile", p.Source("port"))
+	}
+}
+
+func TestParserRequiredFieldMissing(t *testing.T) {
+	var cfg precedenceTestConfig
+	p := NewParser()
+	p.ErrorHandling = ContinueOnError
+	p.SetOutput(&bytes.Buffer{})
+	err := p.ParseArgs("", &cfg, nil)
+	rfe, ok := err.(*RequiredFieldError)
+	if !ok {
+		t.Fatalf("ParseArgs err = %v (%T), want *RequiredFieldError", err, err)
+	}
+	if len(rfe.Fields) != 1 || rfe.Fields[0] != "host" {
+		t.Fatalf("rfe.Fields = %v, want [host]", rfe.Fields)
+	}
+}
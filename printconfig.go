@@ -0,0 +1,142 @@
+package cfgp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// buildNestedMap mirrors v's struct shape into a map, the same way the
+// map-based Decoders expect to find it: leaf values as their setValue
+// string form, nested non-anonymous structs as sub-maps keyed by field
+// name, anonymous structs flattened into the enclosing map.
+func buildNestedMap(v reflect.Value) map[string]interface{} {
+	out := make(map[string]interface{})
+	typeOfT := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		fieldValue := v.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+		f := typeOfT.Field(i)
+		name := strings.ToLower(f.Name)
+		if n, ok := nameFromTags(f); ok {
+			name = n
+		}
+		isTextUnmarshaler := fieldValue.CanAddr() && fieldValue.Addr().Type().Implements(textUnmarshalerType)
+		if fieldValue.Kind() == reflect.Struct && !isTextUnmarshaler {
+			sub := buildNestedMap(fieldValue)
+			if f.Anonymous {
+				for k, v := range sub {
+					out[k] = v
+				}
+				continue
+			}
+			sectionKey := name
+			if s2, ok := sectionFromTags(f); ok {
+				sectionKey = s2
+			}
+			out[sectionKey] = sub
+			continue
+		}
+		out[name] = formatValue(fieldValue)
+	}
+	return out
+}
+
+func encodeJSON(w io.Writer, m map[string]interface{}) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+func encodeYAML(w io.Writer, m map[string]interface{}) error {
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func encodeTOML(w io.Writer, m map[string]interface{}) error {
+	return toml.NewEncoder(w).Encode(m)
+}
+
+// encodeINI writes v back out as INI text, sections in the same order
+// iniDecoder would read them back from.
+func encodeINI(w io.Writer, v reflect.Value) error {
+	return encodeINISection(w, "", v)
+}
+
+func encodeINISection(w io.Writer, section string, v reflect.Value) error {
+	typeOfT := v.Type()
+	type nested struct {
+		name     string
+		anon     bool
+		override bool
+		v        reflect.Value
+	}
+	var keys []string
+	values := make(map[string]string)
+	var subsections []nested
+	for i := 0; i < v.NumField(); i++ {
+		fieldValue := v.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+		f := typeOfT.Field(i)
+		name := strings.ToLower(f.Name)
+		if n, ok := nameFromTags(f); ok {
+			name = n
+		}
+		isTextUnmarshaler := fieldValue.CanAddr() && fieldValue.Addr().Type().Implements(textUnmarshalerType)
+		if fieldValue.Kind() == reflect.Struct && !isTextUnmarshaler {
+			sectionName, override := name, false
+			if s2, ok := sectionFromTags(f); ok {
+				sectionName, override = s2, true
+			}
+			subsections = append(subsections, nested{name: sectionName, anon: f.Anonymous, override: override, v: fieldValue})
+			continue
+		}
+		keys = append(keys, name)
+		values[name] = formatValue(fieldValue)
+	}
+	if section != "" && len(keys) > 0 {
+		if _, err := fmt.Fprintf(w, "[%s]\n", section); err != nil {
+			return err
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", k, values[k]); err != nil {
+			return err
+		}
+	}
+	for _, sub := range subsections {
+		subSection := section
+		switch {
+		case sub.override:
+			subSection = sub.name
+		case !sub.anon:
+			if subSection == "" {
+				subSection = sub.name
+			} else {
+				subSection = subSection + "." + sub.name
+			}
+		}
+		if err := encodeINISection(w, subSection, sub.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package cfgp
+
+import (
+	"bytes"
+	"testing"
+)
+
+type printConfigCollisionConfig struct {
+	PrintConfig bool `cfgp:"name=print-config"`
+}
+
+// A field that resolves to the reserved -print-config flag name must make
+// ParseArgs return an error instead of panicking through flag.FlagSet.Var.
+func TestParserParseArgsRejectsPrintConfigNameCollision(t *testing.T) {
+	var cfg printConfigCollisionConfig
+	p := NewParser()
+	p.ErrorHandling = ContinueOnError
+	p.SetOutput(&bytes.Buffer{})
+	err := p.ParseArgs("", &cfg, nil)
+	if err == nil {
+		t.Fatal("ParseArgs returned nil error for a field colliding with -print-config, want a non-nil error")
+	}
+}
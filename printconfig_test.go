@@ -0,0 +1,67 @@
+package cfgp
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildNestedMapTextUnmarshalerLeaf(t *testing.T) {
+	type Config struct {
+		Start time.Time
+		Port  int
+	}
+	cfg := Config{Start: time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC), Port: 8080}
+	m := buildNestedMap(reflect.ValueOf(&cfg).Elem())
+	start, ok := m["start"].(string)
+	if !ok || start == "" {
+		t.Fatalf("m[%q] = %#v, want a non-empty marshaled string", "start", m["start"])
+	}
+	if m["port"] != "8080" {
+		t.Fatalf("m[%q] = %#v, want %q", "port", m["port"], "8080")
+	}
+}
+
+func TestEncodeINITextUnmarshalerLeaf(t *testing.T) {
+	type Config struct {
+		Start time.Time
+		Port  int
+	}
+	cfg := Config{Start: time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC), Port: 8080}
+	var buf bytes.Buffer
+	if err := encodeINI(&buf, reflect.ValueOf(&cfg).Elem()); err != nil {
+		t.Fatalf("encodeINI: %v", err)
+	}
+	out := buf.String()
+	if !bytes.Contains(buf.Bytes(), []byte("start=2020-01-02T15:04:05Z")) {
+		t.Fatalf("encodeINI output missing rendered start field, got:\n%s", out)
+	}
+}
+
+// A section-tagged nested field must round-trip through -print-config
+// under the same section name it was actually loaded from, not the
+// field's own name.
+func TestPrintConfigRoundTripsTaggedSection(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/conf.ini"
+	if err := os.WriteFile(path, []byte("[http]\nhost=example.com\nport=8080\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	var cfg sectionTaggedConfig
+	p := NewParser()
+	p.ErrorHandling = ContinueOnError
+	var out bytes.Buffer
+	p.SetOutput(&out)
+	err := p.ParseArgs(path, &cfg, []string{"-print-config"})
+	if err != ErrPrintConfig {
+		t.Fatalf("ParseArgs err = %v, want ErrPrintConfig", err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("[http]")) {
+		t.Fatalf("printed config = %q, want it to contain [http]", out.String())
+	}
+	if bytes.Contains(out.Bytes(), []byte("[server]")) {
+		t.Fatalf("printed config = %q, want it not to fall back to the field name [server]", out.String())
+	}
+}
@@ -0,0 +1,50 @@
+package cfgp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Server is tagged to load from the "http" section/key even though the
+// Go field is named Server, exercising sectionFromTags overriding the
+// nested lookup key in every map-based Decoder, not just the INI one.
+type sectionTaggedConfig struct {
+	Server struct {
+		Host string
+		Port int
+	} `cfgp:"section=http"`
+}
+
+func TestApplyMapHonoursSectionTagViaYAML(t *testing.T) {
+	var cfg sectionTaggedConfig
+	_, err := yamlDecoder{}.Decode(strings.NewReader("http:\n  host: example.com\n  port: 8080\n"), reflect.ValueOf(&cfg).Elem())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if cfg.Server.Host != "example.com" || cfg.Server.Port != 8080 {
+		t.Fatalf("Server = %+v, want Host=example.com Port=8080 read from the http section", cfg.Server)
+	}
+}
+
+func TestApplyMapHonoursSectionTagViaJSON(t *testing.T) {
+	var cfg sectionTaggedConfig
+	_, err := jsonDecoder{}.Decode(strings.NewReader(`{"http": {"host": "example.com", "port": 8080}}`), reflect.ValueOf(&cfg).Elem())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if cfg.Server.Host != "example.com" || cfg.Server.Port != 8080 {
+		t.Fatalf("Server = %+v, want Host=example.com Port=8080 read from the http section", cfg.Server)
+	}
+}
+
+func TestApplyMapHonoursSectionTagViaTOML(t *testing.T) {
+	var cfg sectionTaggedConfig
+	_, err := tomlDecoder{}.Decode(strings.NewReader("[http]\nhost = \"example.com\"\nport = 8080\n"), reflect.ValueOf(&cfg).Elem())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if cfg.Server.Host != "example.com" || cfg.Server.Port != 8080 {
+		t.Fatalf("Server = %+v, want Host=example.com Port=8080 read from the http section", cfg.Server)
+	}
+}
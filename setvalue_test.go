@@ -0,0 +1,55 @@
+package cfgp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSetValueDuration(t *testing.T) {
+	var d time.Duration
+	if err := setValue(reflect.ValueOf(&d).Elem(), "5m"); err != nil {
+		t.Fatalf("setValue: %v", err)
+	}
+	if d != 5*time.Minute {
+		t.Fatalf("d = %v, want 5m", d)
+	}
+}
+
+func TestSetValueFloat(t *testing.T) {
+	var f float64
+	if err := setValue(reflect.ValueOf(&f).Elem(), "3.14"); err != nil {
+		t.Fatalf("setValue: %v", err)
+	}
+	if f != 3.14 {
+		t.Fatalf("f = %v, want 3.14", f)
+	}
+}
+
+func TestSetValueUint(t *testing.T) {
+	var u uint
+	if err := setValue(reflect.ValueOf(&u).Elem(), "42"); err != nil {
+		t.Fatalf("setValue: %v", err)
+	}
+	if u != 42 {
+		t.Fatalf("u = %v, want 42", u)
+	}
+}
+
+func TestSetValueSlice(t *testing.T) {
+	var ints []int
+	if err := setValue(reflect.ValueOf(&ints).Elem(), "1, 2,3"); err != nil {
+		t.Fatalf("setValue: %v", err)
+	}
+	want := []int{1, 2, 3}
+	if !reflect.DeepEqual(ints, want) {
+		t.Fatalf("ints = %v, want %v", ints, want)
+	}
+}
+
+func TestDescribeTypeSlice(t *testing.T) {
+	desc := describeType(reflect.TypeOf([]int{}))
+	if desc != "a comma-separated list of int values" {
+		t.Fatalf("describeType([]int) = %q", desc)
+	}
+}
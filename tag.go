@@ -0,0 +1,136 @@
+package cfgp
+
+import (
+	"reflect"
+	"strings"
+)
+
+// tagInfo is the parsed form of a `cfgp` struct tag, in either of the two
+// formats parseTag understands.
+type tagInfo struct {
+	name       string
+	hasName    bool
+	help       string
+	hasHelp    bool
+	section    string
+	hasSection bool
+	env        string
+	required   bool
+}
+
+// parseTag reads the `cfgp` tag of f. Two formats are understood:
+//
+//   - the legacy positional one, <name>,<help message>,<section in file>,
+//     kept for backward compatibility;
+//   - a named-key one, e.g. "name=port,help=TCP port,section=http,env=PORT,required",
+//     which additionally allows overriding the env variable name and
+//     marking the field as required.
+//
+// The named format is recognised by its first comma-separated part being
+// the bare "required" keyword or one of the named keys followed by "=";
+// the legacy format's first part is always a bare flag name with no "=",
+// so this holds even when a later part (typically the help message)
+// happens to contain an "=" of its own.
+func parseTag(f reflect.StructField) tagInfo {
+	t := f.Tag.Get("cfgp")
+	if t == "" {
+		return tagInfo{}
+	}
+	if looksNamed(strings.TrimSpace(strings.SplitN(t, ",", 2)[0])) {
+		return parseNamedTag(t)
+	}
+	return parsePositionalTag(t)
+}
+
+// looksNamed reports whether part, the first comma-separated component of
+// a `cfgp` tag, marks the tag as using the named-key format.
+func looksNamed(part string) bool {
+	if part == "required" {
+		return true
+	}
+	kv := strings.SplitN(part, "=", 2)
+	if len(kv) != 2 {
+		return false
+	}
+	switch kv[0] {
+	case "name", "help", "section", "env":
+		return true
+	default:
+		return false
+	}
+}
+
+func parseNamedTag(t string) tagInfo {
+	var info tagInfo
+	for _, part := range strings.Split(t, ",") {
+		part = strings.TrimSpace(part)
+		if part == "required" {
+			info.required = true
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "name":
+			info.name, info.hasName = value, true
+		case "help":
+			info.help, info.hasHelp = value, true
+		case "section":
+			info.section, info.hasSection = value, true
+		case "env":
+			info.env = value
+		}
+	}
+	return info
+}
+
+func parsePositionalTag(t string) tagInfo {
+	parts := strings.Split(t, ",")
+	if len(parts) != 3 {
+		return tagInfo{}
+	}
+	return tagInfo{
+		name:       parts[0],
+		hasName:    parts[0] != "",
+		help:       parts[1],
+		hasHelp:    parts[1] != "",
+		section:    parts[2],
+		hasSection: parts[2] != "",
+	}
+}
+
+func nameFromTags(f reflect.StructField) (string, bool) {
+	info := parseTag(f)
+	return info.name, info.hasName
+}
+
+func helpMessageFromTags(f reflect.StructField) (string, bool) {
+	info := parseTag(f)
+	return info.help, info.hasHelp
+}
+
+// sectionFromTags returns the configuration-file section a field belongs
+// to, as set by the third positional component, or the "section" key, of
+// a `cfgp` tag. Fields without one are looked up in the section implied
+// by their nesting, see walkFields.
+func sectionFromTags(f reflect.StructField) (string, bool) {
+	info := parseTag(f)
+	return info.section, info.hasSection
+}
+
+// envFromTags returns the env variable name explicitly set via the "env"
+// key of a `cfgp` tag, overriding the name walkFields would otherwise
+// derive from the field's (and its parents') names.
+func envFromTags(f reflect.StructField) (string, bool) {
+	info := parseTag(f)
+	return info.env, info.env != ""
+}
+
+// requiredFromTags reports whether f was marked "required" in its `cfgp`
+// tag.
+func requiredFromTags(f reflect.StructField) bool {
+	return parseTag(f).required
+}
@@ -0,0 +1,71 @@
+package cfgp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tagField(tag string) reflect.StructField {
+	return reflect.StructField{Tag: reflect.StructTag(`cfgp:"` + tag + `"`)}
+}
+
+func TestParseTagPositional(t *testing.T) {
+	info := parseTag(tagField("hostname,the host to bind to,net"))
+	if info.name != "hostname" || !info.hasName {
+		t.Fatalf("name = %q, hasName = %v", info.name, info.hasName)
+	}
+	if info.help != "the host to bind to" || !info.hasHelp {
+		t.Fatalf("help = %q, hasHelp = %v", info.help, info.hasHelp)
+	}
+	if info.section != "net" || !info.hasSection {
+		t.Fatalf("section = %q, hasSection = %v", info.section, info.hasSection)
+	}
+}
+
+// A legacy positional tag whose help text contains an "=" must still be
+// parsed positionally, not mistaken for the named-key format.
+func TestParseTagPositionalWithEqualsInHelp(t *testing.T) {
+	info := parseTag(tagField("hostname,use form key=value here,net"))
+	if info.name != "hostname" || !info.hasName {
+		t.Fatalf("name = %q, hasName = %v, want %q", info.name, info.hasName, "hostname")
+	}
+	if info.help != "use form key=value here" {
+		t.Fatalf("help = %q", info.help)
+	}
+}
+
+func TestParseTagNamed(t *testing.T) {
+	info := parseTag(tagField("name=port,help=TCP port,section=http,env=PORT,required"))
+	if info.name != "port" || !info.hasName {
+		t.Fatalf("name = %q, hasName = %v", info.name, info.hasName)
+	}
+	if info.help != "TCP port" || !info.hasHelp {
+		t.Fatalf("help = %q, hasHelp = %v", info.help, info.hasHelp)
+	}
+	if info.section != "http" || !info.hasSection {
+		t.Fatalf("section = %q, hasSection = %v", info.section, info.hasSection)
+	}
+	if info.env != "PORT" {
+		t.Fatalf("env = %q", info.env)
+	}
+	if !info.required {
+		t.Fatal("required = false, want true")
+	}
+}
+
+func TestParseTagNamedBareRequired(t *testing.T) {
+	info := parseTag(tagField("required"))
+	if !info.required {
+		t.Fatal("required = false, want true")
+	}
+	if info.hasName {
+		t.Fatal("hasName = true, want false")
+	}
+}
+
+func TestParseTagEmpty(t *testing.T) {
+	info := parseTag(reflect.StructField{})
+	if info.hasName || info.hasHelp || info.hasSection || info.required {
+		t.Fatalf("expected zero-value tagInfo for missing tag, got %+v", info)
+	}
+}
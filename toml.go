@@ -0,0 +1,21 @@
+package cfgp
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlDecoder implements Decoder for TOML files, via
+// github.com/BurntSushi/toml. Keys are matched against fields the same
+// way as everywhere else in cfgp, see applyMap.
+type tomlDecoder struct{}
+
+func (tomlDecoder) Decode(r io.Reader, v reflect.Value) (map[string]bool, error) {
+	raw := make(map[string]interface{})
+	if _, err := toml.DecodeReader(r, &raw); err != nil {
+		return nil, err
+	}
+	return applyMap(raw, v)
+}
@@ -0,0 +1,47 @@
+package cfgp
+
+import (
+	"reflect"
+	"testing"
+)
+
+type HTTPConfig struct {
+	Host string
+	Port int
+}
+
+type nestedConfig struct {
+	HTTPConfig // anonymous: flattens into the enclosing level
+	DB         struct {
+		Name string
+	}
+}
+
+func TestWalkFieldsNestedAndAnonymousNaming(t *testing.T) {
+	var cfg nestedConfig
+	v := reflect.ValueOf(&cfg).Elem()
+
+	type visit struct{ flagName, envName, section string }
+	var got []visit
+	walkFields(v, nil, nil, "", func(f reflect.StructField, fieldValue reflect.Value, flagName, envName, section, name string) {
+		got = append(got, visit{flagName, envName, section})
+	})
+
+	want := map[string]visit{
+		"host":    {"host", "HOST", ""},
+		"port":    {"port", "PORT", ""},
+		"db.name": {"db.name", "DB_NAME", "db"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("visited %d fields, want %d: %+v", len(got), len(want), got)
+	}
+	for _, v := range got {
+		w, ok := want[v.flagName]
+		if !ok {
+			t.Fatalf("unexpected flagName %q", v.flagName)
+		}
+		if v != w {
+			t.Errorf("for %q: got %+v, want %+v", v.flagName, v, w)
+		}
+	}
+}
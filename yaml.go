@@ -0,0 +1,27 @@
+package cfgp
+
+import (
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// yamlDecoder implements Decoder for YAML files, via gopkg.in/yaml.v2.
+// Keys are matched against fields the same way as everywhere else in
+// cfgp: the `cfgp` tag name if present, otherwise the lowercased field
+// name, see applyMap.
+type yamlDecoder struct{}
+
+func (yamlDecoder) Decode(r io.Reader, v reflect.Value) (map[string]bool, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	return applyMap(raw, v)
+}
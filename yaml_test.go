@@ -0,0 +1,36 @@
+package cfgp
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestYAMLDecoderDecode(t *testing.T) {
+	type Config struct {
+		Port int
+		Name string
+	}
+	var cfg Config
+	set, err := yamlDecoder{}.Decode(strings.NewReader("port: 9090\nname: svc\n"), reflect.ValueOf(&cfg).Elem())
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if cfg.Port != 9090 || cfg.Name != "svc" {
+		t.Fatalf("cfg = %+v, want Port=9090 Name=svc", cfg)
+	}
+	if !set["port"] || !set["name"] {
+		t.Fatalf("set = %v, want both port and name recorded", set)
+	}
+}
+
+func TestEncodeYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := encodeYAML(&buf, map[string]interface{}{"port": "9090"}); err != nil {
+		t.Fatalf("encodeYAML: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("port: \"9090\"")) {
+		t.Fatalf("encodeYAML output = %q, want it to contain port: \"9090\"", buf.String())
+	}
+}